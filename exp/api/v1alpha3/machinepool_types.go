@@ -0,0 +1,282 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+const (
+	// MachinePoolFinalizer is used to ensure deletion of dependencies (nodes, infra).
+	MachinePoolFinalizer = "machinepool.cluster.x-k8s.io"
+
+	// MachinePoolSpecHashAnnotation is the annotation written on a Node when it is
+	// first adopted by a MachinePool, recording a hash of the pool spec at adoption
+	// time so later reconciles can detect drift between the Node and the current spec.
+	MachinePoolSpecHashAnnotation = "cluster.x-k8s.io/machinepool-spec-hash"
+
+	// MachinePoolEmptySinceAnnotation records the time a Node was first observed to
+	// be empty of non-daemonset, non-mirror pods, so EmptinessTTL can be measured
+	// across reconciles.
+	MachinePoolEmptySinceAnnotation = "cluster.x-k8s.io/empty-since"
+
+	// MachinePoolConsolidationCandidateAnnotation is written on a Node once it has
+	// been empty for at least EmptinessTTL and has been scaled out of the
+	// MachinePool's ProviderIDList, so infra providers know to cordon/drain it
+	// before deleting the backing instance. The value is the time the Node was
+	// marked as a candidate.
+	MachinePoolConsolidationCandidateAnnotation = "cluster.x-k8s.io/consolidation-candidate"
+
+	// MachinePoolExpiredAnnotation is written on a Node once its age has exceeded
+	// the MachinePool's MaxNodeLifetime.
+	MachinePoolExpiredAnnotation = "cluster.x-k8s.io/expired"
+
+	// MachinePoolTerminatingAnnotation is written by the MachinePoolReconciler on a
+	// Node that has been removed from the desired set of replicas (ProviderIDList
+	// shrink, expiration, drift replacement, or an external deletion signal). The
+	// Terminator controller watches for this annotation and drains the Node before
+	// infra providers are allowed to delete the backing instance.
+	MachinePoolTerminatingAnnotation = "cluster.x-k8s.io/terminating"
+
+	// MachinePoolDrainedAnnotation is written by the Terminator controller once a
+	// terminating Node has been cordoned and its evictable pods have been evicted
+	// (or eviction has timed out). Infra MachinePool controllers must wait for this
+	// annotation before terminating the backing instance.
+	MachinePoolDrainedAnnotation = "cluster.x-k8s.io/drained"
+
+	// MachinePoolEvictionStartedAnnotation records the time the Terminator began
+	// evicting pods from a Node, so EvictionTimeout can be measured across reconciles.
+	MachinePoolEvictionStartedAnnotation = "cluster.x-k8s.io/eviction-started"
+)
+
+// Per-node condition types set by the Terminator controller on a terminating Node's
+// Status.Conditions, reporting how far through the drain it has progressed.
+const (
+	// NodeCordoned reports whether the Terminator has marked the Node unschedulable.
+	NodeCordoned corev1.NodeConditionType = "Cordoned"
+
+	// NodeDrained reports whether the Node's evictable pods have all been evicted.
+	NodeDrained corev1.NodeConditionType = "Drained"
+
+	// NodeEvictionTimedOut reports that EvictionTimeout elapsed before all evictable
+	// pods could be evicted; the Node is still marked Drained so termination can
+	// proceed, but operators should investigate the stuck pods.
+	NodeEvictionTimedOut corev1.NodeConditionType = "EvictionTimedOut"
+)
+
+// MachinePoolDriftPolicy controls whether, and when, infrastructure providers should
+// replace Nodes that have drifted from the current MachinePool spec.
+type MachinePoolDriftPolicy string
+
+const (
+	// MachinePoolDriftPolicyWhenEmpty only replaces drifted nodes once they are empty
+	// of non-daemonset, non-mirror pods.
+	MachinePoolDriftPolicyWhenEmpty MachinePoolDriftPolicy = "WhenEmpty"
+
+	// MachinePoolDriftPolicyAlways replaces drifted nodes as soon as drift is detected.
+	MachinePoolDriftPolicyAlways MachinePoolDriftPolicy = "Always"
+
+	// MachinePoolDriftPolicyNever disables drift-triggered replacement; drift is still
+	// surfaced on status but infrastructure providers must not act on it.
+	MachinePoolDriftPolicyNever MachinePoolDriftPolicy = "Never"
+)
+
+// DriftedCondition reports that one or more of a MachinePool's Nodes no longer match
+// the current pool spec.
+const DriftedCondition clusterv1.ConditionType = "Drifted"
+
+// MachinePoolSpec defines the desired state of MachinePool.
+type MachinePoolSpec struct {
+	// ClusterName is the name of the Cluster this object belongs to.
+	// +kubebuilder:validation:MinLength=1
+	ClusterName string `json:"clusterName"`
+
+	// Number of desired machines. Defaults to 1.
+	// This is a pointer to distinguish between explicit zero and not specified.
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// MinReadySeconds is the minimum number of seconds for which a newly created
+	// machine instances should be ready.
+	// Defaults to 0 (machine instance will be considered available as soon as it
+	// is ready)
+	// +optional
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+
+	// ProviderIDList are the identification IDs of machine instances provided by the provider.
+	// This field must match the provider IDs as seen on the node objects corresponding to a machine pool's machine instances.
+	// +optional
+	ProviderIDList []string `json:"providerIDList,omitempty"`
+
+	// Template describes the machines that will be created.
+	Template clusterv1.MachineTemplateSpec `json:"template"`
+
+	// FailureDomains is the list of failure domains this MachinePool should be attached to.
+	// +optional
+	FailureDomains []string `json:"failureDomains,omitempty"`
+
+	// DriftPolicy tells infrastructure providers whether, and when, they should replace
+	// Nodes whose spec hash no longer matches the current Template. Defaults to WhenEmpty.
+	// +optional
+	// +kubebuilder:validation:Enum=WhenEmpty;Always;Never
+	DriftPolicy MachinePoolDriftPolicy `json:"driftPolicy,omitempty"`
+
+	// EmptinessTTL is the duration a Node must be empty of non-daemonset, non-mirror
+	// pods before the consolidation controller scales it out of the MachinePool.
+	// If not set, empty-node consolidation is disabled for this MachinePool.
+	// +optional
+	EmptinessTTL *metav1.Duration `json:"emptinessTTL,omitempty"`
+
+	// MaxNodeLifetime is the maximum duration a Node may exist before it is
+	// considered expired and queued for rolling replacement. If not set, nodes are
+	// never expired by age.
+	// +optional
+	MaxNodeLifetime *metav1.Duration `json:"maxNodeLifetime,omitempty"`
+
+	// MaxUnavailable is the maximum number of expired replicas that may be replaced
+	// at the same time. Defaults to 1.
+	// +optional
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
+
+	// TerminationGracePeriod is how long the Terminator waits, counted from the time
+	// a Node is marked terminating, before it begins evicting pods; the Node is
+	// cordoned during this wait. Defaults to 0.
+	// +optional
+	TerminationGracePeriod *metav1.Duration `json:"terminationGracePeriod,omitempty"`
+
+	// EvictionTimeout is the maximum duration the Terminator will spend evicting a
+	// terminating Node's pods before giving up and marking it Drained anyway.
+	// Defaults to no timeout.
+	// +optional
+	EvictionTimeout *metav1.Duration `json:"evictionTimeout,omitempty"`
+}
+
+// MachinePoolStatus defines the observed state of MachinePool.
+type MachinePoolStatus struct {
+	// NodeRefs will point to the corresponding Nodes if it they exist.
+	// +optional
+	NodeRefs []corev1.ObjectReference `json:"nodeRefs,omitempty"`
+
+	// Replicas is the most recently observed number of replicas.
+	// +optional
+	Replicas int32 `json:"replicas"`
+
+	// The number of ready replicas for this MachinePool. A machine is considered ready when the Node has been created and is "Ready".
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// The number of available replicas (ready for at least minReadySeconds) for this MachinePool.
+	// +optional
+	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
+
+	// Total number of unavailable machine instances targeted by this machine pool.
+	// +optional
+	UnavailableReplicas int32 `json:"unavailableReplicas,omitempty"`
+
+	// FailureReason will be set in the event that there is a terminal problem
+	// reconciling the MachinePool and will contain a succinct value suitable
+	// for machine interpretation.
+	// +optional
+	FailureReason *string `json:"failureReason,omitempty"`
+
+	// FailureMessage will be set in the event that there is a terminal problem
+	// reconciling the MachinePool and will contain a more verbose string suitable
+	// for logging and human consumption.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// Phase represents the current phase of machine pool actuation.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// BootstrapReady is the state of the bootstrap provider.
+	// +optional
+	BootstrapReady bool `json:"bootstrapReady,omitempty"`
+
+	// InfrastructureReady is the state of the infrastructure provider.
+	// +optional
+	InfrastructureReady bool `json:"infrastructureReady,omitempty"`
+
+	// ObservedGeneration is the latest generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions define the current service state of the MachinePool.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// DriftedReplicas is the number of replicas whose Node no longer matches the
+	// current MachinePool spec, as recorded by the Drifted condition.
+	// +optional
+	DriftedReplicas int32 `json:"driftedReplicas,omitempty"`
+
+	// ExpiredReplicas is the number of replicas whose Node has exceeded
+	// MaxNodeLifetime.
+	// +optional
+	ExpiredReplicas int32 `json:"expiredReplicas,omitempty"`
+
+	// ExpiredProviderIDs lists the ProviderIDs that have been removed from
+	// ProviderIDList because their Node exceeded MaxNodeLifetime, so infra
+	// MachinePool controllers can terminate the backing instances.
+	// +optional
+	ExpiredProviderIDs []string `json:"expiredProviderIDs,omitempty"`
+
+	// TerminatingReplicas is the number of replicas whose Node has been removed from
+	// the desired set and is being cordoned/drained by the Terminator controller.
+	// +optional
+	TerminatingReplicas int32 `json:"terminatingReplicas,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=machinepools,scope=Namespaced,categories=cluster-api
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+
+// MachinePool is the Schema for the machinepools API.
+type MachinePool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MachinePoolSpec   `json:"spec,omitempty"`
+	Status MachinePoolStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the set of conditions for this object.
+func (m *MachinePool) GetConditions() clusterv1.Conditions {
+	return m.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (m *MachinePool) SetConditions(conditions clusterv1.Conditions) {
+	m.Status.Conditions = conditions
+}
+
+// +kubebuilder:object:root=true
+
+// MachinePoolList contains a list of MachinePool.
+type MachinePoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MachinePool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MachinePool{}, &MachinePoolList{})
+}
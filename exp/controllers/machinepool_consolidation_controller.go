@@ -0,0 +1,227 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+)
+
+// nodeNameField is the field index name used to look up Pods by the Node they are
+// scheduled on. It is registered once per manager and shared by every controller in
+// this package that needs to list a Node's pods (the consolidation and Terminator
+// controllers).
+const nodeNameField = "spec.nodeName"
+
+// indexPodsByNodeName is the client.IndexerFunc for nodeNameField.
+func indexPodsByNodeName(obj client.Object) []string {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+	if pod.Spec.NodeName == "" {
+		return nil
+	}
+	return []string{pod.Spec.NodeName}
+}
+
+var (
+	nodeNameIndexerOnce sync.Once
+	nodeNameIndexerErr  error
+)
+
+// ensureNodeNameIndexer registers nodeNameField on mgr's field indexer exactly once.
+// The consolidation and Terminator controllers both depend on it and are meant to run
+// on the same manager; mgr.GetFieldIndexer().IndexField returns an "indexer conflict"
+// error if the same field is registered against the same GVK twice.
+func ensureNodeNameIndexer(ctx context.Context, mgr ctrl.Manager) error {
+	nodeNameIndexerOnce.Do(func() {
+		nodeNameIndexerErr = mgr.GetFieldIndexer().IndexField(ctx, &corev1.Pod{}, nodeNameField, indexPodsByNodeName)
+	})
+	return errors.Wrap(nodeNameIndexerErr, "failed to set up spec.nodeName indexer on Pod")
+}
+
+// MachinePoolConsolidationReconciler scales empty Nodes out of a MachinePool once
+// they have been idle of non-daemonset, non-mirror pods for at least EmptinessTTL.
+type MachinePoolConsolidationReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the reconciler with the Manager.
+func (r *MachinePoolConsolidationReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	r.recorder = mgr.GetEventRecorderFor("machinepool-consolidation-controller")
+
+	if err := ensureNodeNameIndexer(ctx, mgr); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&expv1.MachinePool{}).
+		WithOptions(options).
+		Complete(r)
+}
+
+func (r *MachinePoolConsolidationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("machinepool", req.NamespacedName)
+
+	mp := &expv1.MachinePool{}
+	if err := r.Client.Get(ctx, req.NamespacedName, mp); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if mp.Spec.EmptinessTTL == nil {
+		return ctrl.Result{}, nil
+	}
+
+	result, err := getNodeReferences(ctx, r.Client, mp.Spec.ProviderIDList, mp, r.recorder)
+	if err != nil {
+		if errors.Is(err, ErrNoAvailableNodes) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "failed to get node references")
+	}
+
+	for _, ref := range result.references {
+		node := &corev1.Node{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: ref.Name}, node); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to get Node %q", ref.Name)
+		}
+
+		podList := &corev1.PodList{}
+		if err := r.Client.List(ctx, podList, client.MatchingFields{nodeNameField: node.Name}); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to list pods on Node %q", node.Name)
+		}
+
+		if !isNodeEmpty(podList.Items) {
+			if _, ok := node.Annotations[expv1.MachinePoolEmptySinceAnnotation]; ok {
+				delete(node.Annotations, expv1.MachinePoolEmptySinceAnnotation)
+				if err := r.Client.Update(ctx, node); err != nil {
+					return ctrl.Result{}, errors.Wrapf(err, "failed to clear empty-since annotation on Node %q", node.Name)
+				}
+			}
+			continue
+		}
+
+		emptySince, ok := node.Annotations[expv1.MachinePoolEmptySinceAnnotation]
+		if !ok {
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			node.Annotations[expv1.MachinePoolEmptySinceAnnotation] = time.Now().Format(time.RFC3339)
+			if err := r.Client.Update(ctx, node); err != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to annotate Node %q as empty", node.Name)
+			}
+			continue
+		}
+
+		since, err := time.Parse(time.RFC3339, emptySince)
+		if err != nil {
+			log.Error(err, "failed to parse empty-since annotation, resetting", "node", node.Name)
+			node.Annotations[expv1.MachinePoolEmptySinceAnnotation] = time.Now().Format(time.RFC3339)
+			if err := r.Client.Update(ctx, node); err != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to reset empty-since annotation on Node %q", node.Name)
+			}
+			continue
+		}
+
+		if time.Since(since) < mp.Spec.EmptinessTTL.Duration {
+			continue
+		}
+
+		if err := r.consolidate(ctx, mp, node); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to consolidate Node %q", node.Name)
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// consolidate removes node's ProviderID from the MachinePool and marks the Node as a
+// consolidation candidate so infra providers can cordon/drain it before deletion.
+func (r *MachinePoolConsolidationReconciler) consolidate(ctx context.Context, mp *expv1.MachinePool, node *corev1.Node) error {
+	providerIDs := make([]string, 0, len(mp.Spec.ProviderIDList))
+	for _, providerID := range mp.Spec.ProviderIDList {
+		if sameProviderID(providerID, node.Spec.ProviderID, r.recorder) {
+			continue
+		}
+		providerIDs = append(providerIDs, providerID)
+	}
+
+	if len(providerIDs) == len(mp.Spec.ProviderIDList) {
+		// Managed-replicas infra providers don't populate ProviderIDList; fall back to
+		// decrementing replicas so the infra MachinePool controller scales down instead.
+		if mp.Spec.Replicas != nil && *mp.Spec.Replicas > 0 {
+			*mp.Spec.Replicas--
+		}
+	} else {
+		mp.Spec.ProviderIDList = providerIDs
+	}
+
+	if err := r.Client.Update(ctx, mp); err != nil {
+		return errors.Wrap(err, "failed to update MachinePool")
+	}
+
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[expv1.MachinePoolConsolidationCandidateAnnotation] = time.Now().Format(time.RFC3339)
+
+	return r.Client.Update(ctx, node)
+}
+
+// isNodeEmpty returns true if pods contains no non-daemonset, non-mirror pods.
+func isNodeEmpty(pods []corev1.Pod) bool {
+	for _, pod := range pods {
+		if isDaemonSetPod(&pod) || isMirrorPod(&pod) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func isDaemonSetPod(pod *corev1.Pod) bool {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "DaemonSet" {
+			return true
+		}
+	}
+	return false
+}
+
+func isMirrorPod(pod *corev1.Pod) bool {
+	_, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]
+	return ok
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+)
+
+// MachinePoolReconciler reconciles a MachinePool object.
+type MachinePoolReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the reconciler with the Manager.
+func (r *MachinePoolReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	r.recorder = mgr.GetEventRecorderFor("machinepool-controller")
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&expv1.MachinePool{}).
+		WithOptions(options).
+		Complete(r)
+}
+
+func (r *MachinePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := r.Log.WithValues("machinepool", req.NamespacedName)
+
+	mp := &expv1.MachinePool{}
+	if err := r.Client.Get(ctx, req.NamespacedName, mp); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	previousNodeRefs := mp.Status.NodeRefs
+
+	result, err := getNodeReferences(ctx, r.Client, mp.Spec.ProviderIDList, mp, r.recorder)
+	if err != nil {
+		if errors.Is(err, ErrNoAvailableNodes) {
+			log.V(2).Info("No available nodes for machine pool")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "failed to get node references")
+	}
+
+	mp.Status.NodeRefs = result.references
+
+	if err := r.reconcileDrift(ctx, mp, result); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to reconcile node drift")
+	}
+
+	if err := r.reconcileExpiry(ctx, mp, result); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to reconcile node expiry")
+	}
+
+	if err := r.reconcileTermination(ctx, mp, previousNodeRefs, result); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to reconcile node termination")
+	}
+
+	// Update decodes the server's copy into mp, whose status is the stored one, so
+	// keep the status computed above and restore it before writing the subresource.
+	status := mp.Status.DeepCopy()
+	if err := r.Client.Update(ctx, mp); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to update MachinePool")
+	}
+	mp.Status = *status
+
+	if err := r.Client.Status().Update(ctx, mp); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to update MachinePool status")
+	}
+
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+)
+
+const defaultMaxUnavailable = 1
+
+// reconcileExpiry finds Nodes older than MaxNodeLifetime, records them on status,
+// and replaces up to MaxUnavailable of them by removing their ProviderIDs from
+// Spec.ProviderIDList so the pool scales back up with fresh instances.
+func (r *MachinePoolReconciler) reconcileExpiry(ctx context.Context, mp *expv1.MachinePool, result getNodeReferencesResult) error {
+	if mp.Spec.MaxNodeLifetime == nil {
+		return nil
+	}
+
+	maxUnavailable := int32(defaultMaxUnavailable)
+	if mp.Spec.MaxUnavailable != nil {
+		maxUnavailable = *mp.Spec.MaxUnavailable
+	}
+
+	var expiredProviderIDs []string
+	for _, ref := range result.references {
+		node := &corev1.Node{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: ref.Name}, node); err != nil {
+			return errors.Wrapf(err, "failed to get Node %q", ref.Name)
+		}
+
+		if time.Since(node.CreationTimestamp.Time) < mp.Spec.MaxNodeLifetime.Duration {
+			continue
+		}
+
+		expiredProviderIDs = append(expiredProviderIDs, node.Spec.ProviderID)
+
+		if _, ok := node.Annotations[expv1.MachinePoolExpiredAnnotation]; ok {
+			continue
+		}
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[expv1.MachinePoolExpiredAnnotation] = "true"
+		if err := r.Client.Update(ctx, node); err != nil {
+			return errors.Wrapf(err, "failed to annotate Node %q as expired", node.Name)
+		}
+	}
+
+	mp.Status.ExpiredReplicas = int32(len(expiredProviderIDs))
+
+	existingNodeIDs, err := existingNodeProviderIDs(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+
+	inFlight := make([]string, 0, len(mp.Status.ExpiredProviderIDs))
+	for _, providerID := range mp.Status.ExpiredProviderIDs {
+		if _, ok := existingNodeIDs[providerID]; ok {
+			inFlight = append(inFlight, providerID)
+		}
+	}
+	mp.Status.ExpiredProviderIDs = inFlight
+
+	budget := maxUnavailable - int32(len(inFlight))
+	if budget <= 0 || len(expiredProviderIDs) == 0 {
+		return nil
+	}
+
+	toReplace := expiredProviderIDs
+	if int32(len(toReplace)) > budget {
+		toReplace = toReplace[:budget]
+	}
+
+	mp.Spec.ProviderIDList = removeProviderIDs(mp.Spec.ProviderIDList, toReplace, r.recorder)
+	mp.Status.ExpiredProviderIDs = append(mp.Status.ExpiredProviderIDs, toReplace...)
+
+	return nil
+}
+
+// existingNodeProviderIDs returns the set of ProviderIDs of all Nodes currently
+// present in the workload cluster, so callers can tell an in-flight replacement
+// (backing instance not yet terminated) from one whose Node has already been deleted.
+func existingNodeProviderIDs(ctx context.Context, c client.Client) (map[string]struct{}, error) {
+	nodeList := corev1.NodeList{}
+	if err := c.List(ctx, &nodeList); err != nil {
+		return nil, errors.Wrap(err, "failed to List nodes")
+	}
+
+	ids := make(map[string]struct{}, len(nodeList.Items))
+	for _, node := range nodeList.Items {
+		if node.Spec.ProviderID == "" {
+			continue
+		}
+		ids[node.Spec.ProviderID] = struct{}{}
+	}
+
+	return ids, nil
+}
+
+// removeProviderIDs returns providerIDList with every entry that refers to the same
+// instance (per sameProviderID) as one of remove's entries filtered out.
+func removeProviderIDs(providerIDList []string, remove []string, recorder record.EventRecorder) []string {
+	kept := make([]string, 0, len(providerIDList))
+	for _, id := range providerIDList {
+		removed := false
+		for _, removeID := range remove {
+			if sameProviderID(id, removeID, recorder) {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			kept = append(kept, id)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+)
+
+func TestReconcileExpiryReopensBudgetOnceNodeIsGone(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	maxNodeLifetime := metav1.Duration{Duration: time.Hour}
+	maxUnavailable := int32(1)
+
+	oldNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "old-node",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-24 * time.Hour)),
+		},
+		Spec: corev1.NodeSpec{ProviderID: "aws:///old-node"},
+	}
+
+	mp := &expv1.MachinePool{
+		Spec: expv1.MachinePoolSpec{
+			MaxNodeLifetime: &maxNodeLifetime,
+			MaxUnavailable:  &maxUnavailable,
+			ProviderIDList:  []string{"aws:///old-node"},
+		},
+	}
+
+	objs := []runtime.Object{oldNode}
+	r := &MachinePoolReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+		Log:    log.Log,
+	}
+
+	result := getNodeReferencesResult{references: []corev1.ObjectReference{{Name: "old-node"}}}
+	g.Expect(r.reconcileExpiry(context.TODO(), mp, result)).To(Succeed())
+
+	g.Expect(mp.Status.ExpiredProviderIDs).To(ConsistOf("aws:///old-node"))
+	g.Expect(mp.Spec.ProviderIDList).To(BeEmpty())
+
+	// A second expired node shows up while the first replacement is still in
+	// flight (its Node object, and therefore its backing instance, still exists):
+	// the budget must stay closed.
+	newOldNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "another-old-node",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-24 * time.Hour)),
+		},
+		Spec: corev1.NodeSpec{ProviderID: "aws:///another-old-node"},
+	}
+	g.Expect(r.Client.Create(context.TODO(), newOldNode)).To(Succeed())
+	mp.Spec.ProviderIDList = []string{"aws:///another-old-node"}
+
+	result = getNodeReferencesResult{references: []corev1.ObjectReference{{Name: "another-old-node"}}}
+	g.Expect(r.reconcileExpiry(context.TODO(), mp, result)).To(Succeed())
+
+	g.Expect(mp.Status.ExpiredProviderIDs).To(ConsistOf("aws:///old-node"))
+	g.Expect(mp.Spec.ProviderIDList).To(ConsistOf("aws:///another-old-node"))
+
+	// Once the first replaced Node's object is actually gone (infra provider
+	// terminated the instance), the budget must reopen.
+	g.Expect(r.Client.Delete(context.TODO(), oldNode)).To(Succeed())
+
+	g.Expect(r.reconcileExpiry(context.TODO(), mp, result)).To(Succeed())
+
+	g.Expect(mp.Status.ExpiredProviderIDs).To(ConsistOf("aws:///another-old-node"))
+	g.Expect(mp.Spec.ProviderIDList).To(BeEmpty())
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+)
+
+// ErrNoAvailableNodes is returned when none of a MachinePool's ProviderIDs could be
+// matched to a Node in the workload cluster.
+var ErrNoAvailableNodes = errors.New("cannot find nodes with matching ProviderIDs in ProviderIDList")
+
+// getNodeReferencesResult is the result of resolving a MachinePool's ProviderIDList to
+// the corev1.Node objects they refer to.
+type getNodeReferencesResult struct {
+	references []corev1.ObjectReference
+}
+
+// getNodeReferences lists the Nodes in the workload cluster and returns an
+// ObjectReference for each providerID in providerIDList that has a matching Node.
+// Matching is done on each ProviderIDParser's normalized form of the ID (see
+// RegisterProviderIDParser) rather than raw string equality, so providers that
+// format the same instance's ID differently (AZ, casing, project prefixes, ...)
+// still resolve to the same Node. eventObject, typically the MachinePool, is the
+// object a ProviderIDParseFailed event is recorded against if an entry of
+// providerIDList itself cannot be parsed.
+func getNodeReferences(ctx context.Context, c client.Client, providerIDList []string, eventObject runtime.Object, recorder record.EventRecorder) (getNodeReferencesResult, error) {
+	nodeList := corev1.NodeList{}
+	if err := c.List(ctx, &nodeList); err != nil {
+		return getNodeReferencesResult{}, errors.Wrap(err, "failed to List nodes")
+	}
+
+	nodesByNormalizedID := make(map[string]corev1.Node, len(nodeList.Items))
+	for _, node := range nodeList.Items {
+		key, ok := normalizedProviderIDKey(node.Spec.ProviderID, &node, recorder)
+		if !ok {
+			continue
+		}
+		nodesByNormalizedID[key] = node
+	}
+
+	var nodeRefs []corev1.ObjectReference
+	for _, providerID := range providerIDList {
+		key, ok := normalizedProviderIDKey(providerID, eventObject, recorder)
+		if !ok {
+			continue
+		}
+
+		node, ok := nodesByNormalizedID[key]
+		if !ok {
+			continue
+		}
+
+		nodeRefs = append(nodeRefs, corev1.ObjectReference{
+			Kind:       "Node",
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Name:       node.Name,
+			Namespace:  node.Namespace,
+			UID:        node.UID,
+		})
+	}
+
+	if len(nodeRefs) == 0 {
+		return getNodeReferencesResult{}, ErrNoAvailableNodes
+	}
+
+	return getNodeReferencesResult{references: nodeRefs}, nil
+}
+
+// normalizedProviderIDKey resolves providerID's scheme-specific parser and returns
+// a key, made up of the scheme and the parser's normalized form, suitable for
+// indexing and equality comparison. If eventObject is non-nil and the ID cannot be
+// parsed, a ProviderIDParseFailed event is recorded against it.
+func normalizedProviderIDKey(providerID string, eventObject runtime.Object, recorder record.EventRecorder) (string, bool) {
+	scheme, err := providerIDScheme(providerID)
+	if err != nil {
+		if eventObject != nil && recorder != nil {
+			recorder.Eventf(eventObject, corev1.EventTypeWarning, "ProviderIDParseFailed", "failed to parse ProviderID %q: %v", providerID, err)
+		}
+		return "", false
+	}
+
+	normalized, err := parserForScheme(scheme).Normalize(providerID)
+	if err != nil {
+		if eventObject != nil && recorder != nil {
+			recorder.Eventf(eventObject, corev1.EventTypeWarning, "ProviderIDParseFailed", "failed to normalize ProviderID %q: %v", providerID, err)
+		}
+		return "", false
+	}
+
+	return scheme + "|" + normalized, true
+}
+
+// sameProviderID reports whether a and b refer to the same instance, comparing
+// their scheme-specific normalized forms rather than raw string equality.
+func sameProviderID(a, b string, recorder record.EventRecorder) bool {
+	aKey, ok := normalizedProviderIDKey(a, nil, recorder)
+	if !ok {
+		return false
+	}
+	bKey, ok := normalizedProviderIDKey(b, nil, recorder)
+	if !ok {
+		return false
+	}
+	return aKey == bKey
+}
+
+// machinePoolSpecHash returns a stable hash of the parts of a MachinePool's Template
+// that, if changed, should cause an already-adopted Node to be considered drifted.
+func machinePoolSpecHash(mp *expv1.MachinePool) string {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "infrastructureRef=%s/%s;uid=%s;resourceVersion=%s\n",
+		mp.Spec.Template.Spec.InfrastructureRef.Namespace, mp.Spec.Template.Spec.InfrastructureRef.Name,
+		mp.Spec.Template.Spec.InfrastructureRef.UID, mp.Spec.Template.Spec.InfrastructureRef.ResourceVersion)
+
+	dataSecretName := ""
+	if mp.Spec.Template.Spec.Bootstrap.DataSecretName != nil {
+		dataSecretName = *mp.Spec.Template.Spec.Bootstrap.DataSecretName
+	}
+	fmt.Fprintf(h, "dataSecretName=%s\n", dataSecretName)
+
+	version := ""
+	if mp.Spec.Template.Spec.Version != nil {
+		version = *mp.Spec.Template.Spec.Version
+	}
+	fmt.Fprintf(h, "version=%s\n", version)
+
+	labelKeys := make([]string, 0, len(mp.Spec.Template.Labels))
+	for k := range mp.Spec.Template.Labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+	for _, k := range labelKeys {
+		fmt.Fprintf(h, "label=%s=%s\n", k, mp.Spec.Template.Labels[k])
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// reconcileDrift annotates newly adopted Nodes with the current MachinePool spec
+// hash and flags any previously-adopted Node whose stored hash no longer matches.
+func (r *MachinePoolReconciler) reconcileDrift(ctx context.Context, mp *expv1.MachinePool, result getNodeReferencesResult) error {
+	currentHash := machinePoolSpecHash(mp)
+
+	var drifted []string
+	for _, ref := range result.references {
+		node := &corev1.Node{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: ref.Name}, node); err != nil {
+			return errors.Wrapf(err, "failed to get Node %q", ref.Name)
+		}
+
+		storedHash, adopted := node.Annotations[expv1.MachinePoolSpecHashAnnotation]
+		if !adopted {
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			node.Annotations[expv1.MachinePoolSpecHashAnnotation] = currentHash
+			if err := r.Client.Update(ctx, node); err != nil {
+				return errors.Wrapf(err, "failed to annotate Node %q with spec hash", ref.Name)
+			}
+			continue
+		}
+
+		if storedHash != currentHash {
+			drifted = append(drifted, ref.Name)
+		}
+	}
+
+	mp.Status.DriftedReplicas = int32(len(drifted))
+
+	if len(drifted) == 0 {
+		conditions.MarkFalse(mp, expv1.DriftedCondition, "NoDrift", clusterv1.ConditionSeverityInfo, "")
+		return nil
+	}
+
+	conditions.Set(mp, &clusterv1.Condition{
+		Type:    expv1.DriftedCondition,
+		Status:  corev1.ConditionTrue,
+		Reason:  "NodesDrifted",
+		Message: fmt.Sprintf("Nodes %v no longer match the MachinePool spec", drifted),
+	})
+
+	return nil
+}
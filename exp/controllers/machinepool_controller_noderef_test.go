@@ -77,6 +77,14 @@ func TestMachinePoolGetNodeReference(t *testing.T) {
 				ProviderID: "azure://westus2/id-node-4",
 			},
 		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "aws-node-5",
+			},
+			Spec: corev1.NodeSpec{
+				ProviderID: "aws:///id-node-5",
+			},
+		},
 	}
 
 	client := fake.NewFakeClientWithScheme(scheme.Scheme, nodeList...)
@@ -139,13 +147,40 @@ func TestMachinePoolGetNodeReference(t *testing.T) {
 			expected:       nil,
 			err:            ErrNoAvailableNodes,
 		},
+		{
+			name:           "case-insensitive azure provider id match",
+			providerIDList: []string{"azure://WestUS2/ID-Node-4"},
+			expected: &getNodeReferencesResult{
+				references: []corev1.ObjectReference{
+					{Name: "azure-node-4"},
+				},
+			},
+		},
+		{
+			name:           "aws provider id with AZ matches node registered without AZ",
+			providerIDList: []string{"aws://us-east-1/id-node-5"},
+			expected: &getNodeReferencesResult{
+				references: []corev1.ObjectReference{
+					{Name: "aws-node-5"},
+				},
+			},
+		},
+		{
+			name:           "aws provider id without AZ matches node registered with AZ",
+			providerIDList: []string{"aws:///id-node-1"},
+			expected: &getNodeReferencesResult{
+				references: []corev1.ObjectReference{
+					{Name: "node-1"},
+				},
+			},
+		},
 	}
 
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
 			gt := NewWithT(t)
 
-			result, err := r.getNodeReferences(context.TODO(), client, test.providerIDList)
+			result, err := getNodeReferences(context.TODO(), client, test.providerIDList, nil, r.recorder)
 			if test.err == nil {
 				g.Expect(err).To(BeNil())
 			} else {
@@ -167,3 +202,31 @@ func TestMachinePoolGetNodeReference(t *testing.T) {
 
 	}
 }
+
+func TestIsNodeEmpty(t *testing.T) {
+	g := NewWithT(t)
+
+	daemonSetPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "daemonset-pod",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ds"}},
+		},
+	}
+
+	mirrorPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mirror-pod",
+			Annotations: map[string]string{corev1.MirrorPodAnnotationKey: ""},
+		},
+	}
+
+	workloadPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "workload-pod",
+		},
+	}
+
+	g.Expect(isNodeEmpty(nil)).To(BeTrue())
+	g.Expect(isNodeEmpty([]corev1.Pod{daemonSetPod, mirrorPod})).To(BeTrue())
+	g.Expect(isNodeEmpty([]corev1.Pod{daemonSetPod, workloadPod})).To(BeFalse())
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+)
+
+// Annotations the Terminator controller reads, denormalized from the owning
+// MachinePool's spec onto the Node at the moment it is marked terminating, so the
+// Terminator (which only watches Nodes) doesn't need to look its MachinePool up.
+const (
+	terminationGracePeriodAnnotation = "cluster.x-k8s.io/termination-grace-period"
+	evictionTimeoutAnnotation        = "cluster.x-k8s.io/eviction-timeout"
+)
+
+// reconcileTermination annotates Nodes that have just dropped out of the
+// MachinePool's resolved node references (because reconcileExpiry, reconcileDrift-
+// driven replacement, or an external ProviderIDList edit removed them) so the
+// Terminator controller cordons and drains them before the backing instance is
+// deleted. Nodes that no longer exist are skipped. It also reports how many Nodes are
+// currently mid-termination.
+func (r *MachinePoolReconciler) reconcileTermination(ctx context.Context, mp *expv1.MachinePool, previousNodeRefs []corev1.ObjectReference, result getNodeReferencesResult) error {
+	current := make(map[string]struct{}, len(result.references))
+	for _, ref := range result.references {
+		current[ref.Name] = struct{}{}
+	}
+
+	var terminating int32
+	for _, ref := range previousNodeRefs {
+		if _, stillPresent := current[ref.Name]; stillPresent {
+			continue
+		}
+
+		node := &corev1.Node{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: ref.Name}, node); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to get Node %q", ref.Name)
+		}
+
+		if _, ok := node.Annotations[expv1.MachinePoolTerminatingAnnotation]; !ok {
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			node.Annotations[expv1.MachinePoolTerminatingAnnotation] = time.Now().Format(time.RFC3339)
+			if mp.Spec.TerminationGracePeriod != nil {
+				node.Annotations[terminationGracePeriodAnnotation] = mp.Spec.TerminationGracePeriod.Duration.String()
+			}
+			if mp.Spec.EvictionTimeout != nil {
+				node.Annotations[evictionTimeoutAnnotation] = mp.Spec.EvictionTimeout.Duration.String()
+			}
+			if err := r.Client.Update(ctx, node); err != nil {
+				return errors.Wrapf(err, "failed to annotate Node %q as terminating", ref.Name)
+			}
+		}
+
+		if _, drained := node.Annotations[expv1.MachinePoolDrainedAnnotation]; !drained {
+			terminating++
+		}
+	}
+
+	mp.Status.TerminatingReplicas = terminating
+
+	return nil
+}
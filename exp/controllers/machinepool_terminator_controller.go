@@ -0,0 +1,251 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+)
+
+// TerminatorReconciler cordons and, honoring PodDisruptionBudgets, drains Nodes that
+// the MachinePoolReconciler has marked terminating, before infra MachinePool
+// controllers are allowed to delete the backing instance.
+type TerminatorReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+
+	// KubeClient is used to evict pods via the Eviction subresource
+	// (/api/v1/namespaces/{ns}/pods/{name}/eviction), which has no typed
+	// representation in the controller-runtime client.
+	KubeClient kubernetes.Interface
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the reconciler with the Manager.
+func (r *TerminatorReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	r.recorder = mgr.GetEventRecorderFor("machinepool-terminator-controller")
+
+	if r.KubeClient == nil {
+		kubeClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			return errors.Wrap(err, "failed to create kubernetes client")
+		}
+		r.KubeClient = kubeClient
+	}
+
+	if err := ensureNodeNameIndexer(ctx, mgr); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Node{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			_, ok := obj.GetAnnotations()[expv1.MachinePoolTerminatingAnnotation]
+			return ok
+		}))).
+		WithOptions(options).
+		Complete(r)
+}
+
+func (r *TerminatorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("node", req.Name)
+
+	node := &corev1.Node{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: req.Name}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	terminatingSince, terminating := node.Annotations[expv1.MachinePoolTerminatingAnnotation]
+	if !terminating {
+		return ctrl.Result{}, nil
+	}
+	if _, drained := node.Annotations[expv1.MachinePoolDrainedAnnotation]; drained {
+		return ctrl.Result{}, nil
+	}
+
+	since, err := time.Parse(time.RFC3339, terminatingSince)
+	if err != nil {
+		log.Error(err, "failed to parse terminating annotation, treating Node as just marked")
+		since = time.Now()
+	}
+
+	if !node.Spec.Unschedulable {
+		node.Spec.Unschedulable = true
+		if err := r.Client.Update(ctx, node); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to cordon Node %q", node.Name)
+		}
+	}
+	setNodeCondition(node, expv1.NodeCordoned, corev1.ConditionTrue, "Cordoned", "Node cordoned for termination")
+
+	if gracePeriod, ok := node.Annotations[terminationGracePeriodAnnotation]; ok {
+		d, err := time.ParseDuration(gracePeriod)
+		if err == nil && time.Since(since) < d {
+			if err := r.Client.Status().Update(ctx, node); err != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to update Node %q status", node.Name)
+			}
+			return ctrl.Result{RequeueAfter: d - time.Since(since)}, nil
+		}
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.Client.List(ctx, podList, client.MatchingFields{nodeNameField: node.Name}); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to list pods on Node %q", node.Name)
+	}
+
+	evictable := evictablePods(podList.Items)
+
+	if len(evictable) == 0 {
+		return ctrl.Result{}, r.markDrained(ctx, node)
+	}
+
+	evictionStarted, ok := node.Annotations[expv1.MachinePoolEvictionStartedAnnotation]
+	if !ok {
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[expv1.MachinePoolEvictionStartedAnnotation] = time.Now().Format(time.RFC3339)
+		if err := r.Client.Update(ctx, node); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to annotate Node %q with eviction start", node.Name)
+		}
+		evictionStarted = node.Annotations[expv1.MachinePoolEvictionStartedAnnotation]
+	}
+
+	if timeout, ok := node.Annotations[evictionTimeoutAnnotation]; ok {
+		d, err := time.ParseDuration(timeout)
+		startedAt, parseErr := time.Parse(time.RFC3339, evictionStarted)
+		if err == nil && parseErr == nil && time.Since(startedAt) > d {
+			setNodeCondition(node, expv1.NodeEvictionTimedOut, corev1.ConditionTrue, "EvictionTimedOut",
+				"Timed out waiting for pods to be evicted; draining anyway")
+			return ctrl.Result{}, r.markDrained(ctx, node)
+		}
+	}
+
+	for _, pod := range evictable {
+		eviction := &policyv1beta1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+			},
+		}
+
+		if err := r.KubeClient.PolicyV1beta1().Evictions(pod.Namespace).Evict(ctx, eviction); err != nil {
+			if apierrors.IsTooManyRequests(err) {
+				// A PodDisruptionBudget is blocking this eviction; try again next reconcile.
+				continue
+			}
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return ctrl.Result{}, errors.Wrapf(err, "failed to evict pod %s/%s", pod.Namespace, pod.Name)
+		}
+	}
+
+	if err := r.Client.Status().Update(ctx, node); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to update Node %q status", node.Name)
+	}
+
+	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+}
+
+// markDrained sets the Drained condition and annotation so infra providers can
+// terminate the instance. It is called once node has no remaining evictable pods,
+// or once EvictionTimeout has elapsed. The status, with every condition set on node
+// so far, is written before the annotation, because the annotation Update replaces
+// node's in-memory status with the stored one.
+func (r *TerminatorReconciler) markDrained(ctx context.Context, node *corev1.Node) error {
+	setNodeCondition(node, expv1.NodeDrained, corev1.ConditionTrue, "Drained", "Node has no remaining evictable pods")
+	if err := r.Client.Status().Update(ctx, node); err != nil {
+		return errors.Wrapf(err, "failed to update Node %q status", node.Name)
+	}
+
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[expv1.MachinePoolDrainedAnnotation] = "true"
+	return errors.Wrapf(r.Client.Update(ctx, node), "failed to annotate Node %q as drained", node.Name)
+}
+
+// evictablePods returns pods, excluding daemonset and mirror pods, sorted so that
+// pods belonging to a system-critical PriorityClass are evicted last.
+func evictablePods(pods []corev1.Pod) []corev1.Pod {
+	evictable := make([]corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if isDaemonSetPod(&pod) || isMirrorPod(&pod) {
+			continue
+		}
+		evictable = append(evictable, pod)
+	}
+
+	sort.SliceStable(evictable, func(i, j int) bool {
+		return podPriority(&evictable[i]) < podPriority(&evictable[j])
+	})
+
+	return evictable
+}
+
+func podPriority(pod *corev1.Pod) int32 {
+	if pod.Spec.Priority == nil {
+		return 0
+	}
+	return *pod.Spec.Priority
+}
+
+// setNodeCondition upserts a NodeCondition of type conditionType on node.
+func setNodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType, status corev1.ConditionStatus, reason, message string) {
+	now := metav1.Now()
+
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type != conditionType {
+			continue
+		}
+		if node.Status.Conditions[i].Status != status {
+			node.Status.Conditions[i].LastTransitionTime = now
+		}
+		node.Status.Conditions[i].Status = status
+		node.Status.Conditions[i].Reason = reason
+		node.Status.Conditions[i].Message = message
+		return
+	}
+
+	node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+	})
+}
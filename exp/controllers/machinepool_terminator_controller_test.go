@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+)
+
+func TestEvictablePods(t *testing.T) {
+	g := NewWithT(t)
+
+	lowPriority := int32(0)
+	highPriority := int32(1000000000)
+
+	daemonSetPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "daemonset-pod",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ds"}},
+		},
+	}
+
+	systemCriticalPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "system-critical-pod"},
+		Spec:       corev1.PodSpec{Priority: &highPriority},
+	}
+
+	workloadPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload-pod"},
+		Spec:       corev1.PodSpec{Priority: &lowPriority},
+	}
+
+	evictable := evictablePods([]corev1.Pod{daemonSetPod, systemCriticalPod, workloadPod})
+
+	g.Expect(evictable).To(HaveLen(2))
+	g.Expect(evictable[0].Name).To(Equal("workload-pod"))
+	g.Expect(evictable[1].Name).To(Equal("system-critical-pod"))
+}
+
+func TestSetNodeCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	node := &corev1.Node{}
+
+	setNodeCondition(node, expv1.NodeCordoned, corev1.ConditionTrue, "Cordoned", "cordoned for termination")
+	g.Expect(node.Status.Conditions).To(HaveLen(1))
+	g.Expect(node.Status.Conditions[0].Type).To(Equal(expv1.NodeCordoned))
+	g.Expect(node.Status.Conditions[0].Status).To(Equal(corev1.ConditionTrue))
+
+	setNodeCondition(node, expv1.NodeDrained, corev1.ConditionTrue, "Drained", "drained")
+	g.Expect(node.Status.Conditions).To(HaveLen(2))
+
+	setNodeCondition(node, expv1.NodeCordoned, corev1.ConditionTrue, "Cordoned", "still cordoned")
+	g.Expect(node.Status.Conditions).To(HaveLen(2))
+}
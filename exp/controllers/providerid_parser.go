@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ProviderIDParser normalizes a provider's ProviderID format so that IDs referring
+// to the same instance, but written differently by different tooling, compare equal.
+// Out-of-tree infrastructure providers can supply their own implementation via
+// RegisterProviderIDParser instead of relying on the built-ins below.
+type ProviderIDParser interface {
+	// Scheme is the ProviderID scheme this parser handles, e.g. "aws".
+	Scheme() string
+
+	// Normalize returns a canonical form of providerID suitable for equality
+	// comparison, stripping anything the provider considers insignificant.
+	Normalize(providerID string) (string, error)
+
+	// Equal reports whether a and b refer to the same instance.
+	Equal(a, b string) bool
+}
+
+var providerIDParsers = map[string]ProviderIDParser{}
+
+// RegisterProviderIDParser registers a ProviderIDParser for its Scheme(), overriding
+// any parser previously registered for that scheme. It is typically called from an
+// infrastructure provider's init().
+func RegisterProviderIDParser(parser ProviderIDParser) {
+	providerIDParsers[parser.Scheme()] = parser
+}
+
+func init() {
+	RegisterProviderIDParser(newBasicProviderIDParser("aws", normalizeAWSProviderID))
+	RegisterProviderIDParser(newBasicProviderIDParser("azure", normalizeCaseInsensitiveProviderID))
+	RegisterProviderIDParser(newBasicProviderIDParser("gce", normalizeIdentityProviderID))
+	RegisterProviderIDParser(newBasicProviderIDParser("vsphere", normalizeIdentityProviderID))
+	RegisterProviderIDParser(newBasicProviderIDParser("kind", normalizeIdentityProviderID))
+}
+
+// parserForScheme returns the ProviderIDParser registered for scheme, falling back
+// to a parser that treats the ID as an opaque, case-sensitive string.
+func parserForScheme(scheme string) ProviderIDParser {
+	if parser, ok := providerIDParsers[scheme]; ok {
+		return parser
+	}
+	return newBasicProviderIDParser(scheme, normalizeIdentityProviderID)
+}
+
+// providerIDScheme returns the scheme portion of a providerID of the form
+// "<scheme>://<host>/<path>".
+func providerIDScheme(providerID string) (string, error) {
+	idx := strings.Index(providerID, "://")
+	if idx <= 0 {
+		return "", errors.Errorf("invalid ProviderID %q: missing scheme", providerID)
+	}
+	return providerID[:idx], nil
+}
+
+// basicProviderIDParser implements ProviderIDParser in terms of a single
+// normalization function, which is how all of the built-in parsers are defined.
+type basicProviderIDParser struct {
+	scheme    string
+	normalize func(providerID string) (string, error)
+}
+
+func newBasicProviderIDParser(scheme string, normalize func(string) (string, error)) *basicProviderIDParser {
+	return &basicProviderIDParser{scheme: scheme, normalize: normalize}
+}
+
+func (p *basicProviderIDParser) Scheme() string { return p.scheme }
+
+func (p *basicProviderIDParser) Normalize(providerID string) (string, error) {
+	return p.normalize(providerID)
+}
+
+func (p *basicProviderIDParser) Equal(a, b string) bool {
+	na, err := p.Normalize(a)
+	if err != nil {
+		return false
+	}
+	nb, err := p.Normalize(b)
+	if err != nil {
+		return false
+	}
+	return na == nb
+}
+
+func normalizeIdentityProviderID(providerID string) (string, error) {
+	return providerID, nil
+}
+
+func normalizeCaseInsensitiveProviderID(providerID string) (string, error) {
+	return strings.ToLower(providerID), nil
+}
+
+// normalizeAWSProviderID drops the availability-zone host segment from an AWS
+// ProviderID (aws://<az>/<instance-id>), since the same instance ID is sometimes
+// reported with and without its AZ.
+func normalizeAWSProviderID(providerID string) (string, error) {
+	idx := strings.Index(providerID, "://")
+	if idx < 0 {
+		return "", errors.Errorf("invalid AWS ProviderID %q: missing scheme", providerID)
+	}
+	scheme, rest := providerID[:idx], providerID[idx+3:]
+
+	slash := strings.Index(rest, "/")
+	if slash < 0 {
+		return "", errors.Errorf("invalid AWS ProviderID %q: missing instance path", providerID)
+	}
+
+	return scheme + ":///" + rest[slash+1:], nil
+}